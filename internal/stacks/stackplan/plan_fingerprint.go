@@ -0,0 +1,97 @@
+package stackplan
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+
+	"github.com/hashicorp/terraform/internal/collections"
+	"github.com/hashicorp/terraform/internal/stacks/stackaddrs"
+)
+
+// Fingerprint computes a stable SHA-256 digest over wireForm -- which must
+// be p's own already-serialized wire form, typically produced by whatever
+// this package uses to write a plan to the plan file -- combined with a
+// canonicalized encoding of p's component dependency graph.
+//
+// wireForm is included via its own digest rather than concatenated
+// directly with the canonical dependency bytes, so that the boundary
+// between the two is never ambiguous: without that, two different
+// (wireForm, dependency-set) pairs could in principle concatenate to the
+// same byte stream and collide. The dependency graph is canonicalized
+// separately because collections.Set iteration order isn't guaranteed to
+// be stable across equivalent plans, and the same plan encoded twice
+// should always produce the same fingerprint. A caller that wants this
+// fingerprint to double as an integrity or signature check must pass the
+// exact wireForm that was persisted.
+func (p *Plan) Fingerprint(wireForm []byte) [32]byte {
+	wireFormDigest := sha256.Sum256(wireForm)
+
+	h := sha256.New()
+	h.Write(wireFormDigest[:])
+	h.Write(p.canonicalDependencyBytes())
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// canonicalDependencyBytes produces a deterministic byte-for-byte encoding
+// of the plan's component dependency graph, independent of the iteration
+// order of any collections.Set values used to build it in memory.
+//
+// This only covers the dependency graph, not the rest of the plan: the
+// rest of the plan's content (resource instance changes, output values,
+// and so on) is expected to already be covered by wireForm as passed to
+// Fingerprint.
+func (p *Plan) canonicalDependencyBytes() []byte {
+	var buf []byte
+
+	instAddrs := make([]stackaddrs.AbsComponentInstance, 0, p.Components.Len())
+	for _, elem := range p.Components.Elems() {
+		instAddrs = append(instAddrs, elem.Key)
+	}
+	sort.Slice(instAddrs, func(i, j int) bool {
+		return instAddrs[i].String() < instAddrs[j].String()
+	})
+
+	for _, addr := range instAddrs {
+		buf = appendCanonicalString(buf, addr.String())
+
+		deps := canonicalDependencyOrder(p.Components.Get(addr).Dependencies)
+		buf = appendCanonicalUint(buf, uint64(len(deps)))
+		for _, dep := range deps {
+			buf = appendCanonicalString(buf, dep.String())
+		}
+	}
+
+	return buf
+}
+
+// canonicalDependencyOrder returns the members of a component's dependency
+// set sorted by stack path and then by component name, so that two sets
+// built up in different insertion orders always produce the same slice.
+func canonicalDependencyOrder(deps collections.Set[stackaddrs.AbsComponent]) []stackaddrs.AbsComponent {
+	ret := deps.Elems()
+	sort.Slice(ret, func(i, j int) bool {
+		return componentSortKey(ret[i]) < componentSortKey(ret[j])
+	})
+	return ret
+}
+
+// componentSortKey sorts by the containing stack's path first and then by
+// component name, matching how a human would expect a plan diff to be
+// organized.
+func componentSortKey(addr stackaddrs.AbsComponent) string {
+	return addr.Stack.String() + "\x00" + addr.Item.Name
+}
+
+func appendCanonicalString(buf []byte, s string) []byte {
+	buf = appendCanonicalUint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendCanonicalUint(buf []byte, v uint64) []byte {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	return append(buf, tmp[:]...)
+}
@@ -0,0 +1,130 @@
+package stackplan
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/collections"
+	"github.com/hashicorp/terraform/internal/stacks/stackaddrs"
+)
+
+// TestPlan_fingerprintStableUnderInsertionOrder checks only the
+// order-invariance property that canonicalization exists to provide: it
+// builds what should be "the same" plan twice, varying only the insertion
+// order of the in-memory dependency sets, and insists that the
+// canonicalized dependency encoding -- and therefore the fingerprint
+// derived from it -- comes out byte-identical both times for the same
+// wire form.
+//
+// This is not a round-trip encode/decode test: this package doesn't (yet)
+// have a decode path for the canonicalized dependency encoding to round-trip
+// through, so unlike the state instance-object encode test this only
+// exercises one direction.
+func TestPlan_fingerprintStableUnderInsertionOrder(t *testing.T) {
+	cmpAAddr := stackaddrs.AbsComponent{
+		Stack: stackaddrs.RootStackInstance,
+		Item:  stackaddrs.Component{Name: "a"},
+	}
+	cmpBAddr := stackaddrs.AbsComponent{
+		Stack: stackaddrs.RootStackInstance,
+		Item:  stackaddrs.Component{Name: "b"},
+	}
+	cmpCInstAddr := stackaddrs.AbsComponentInstance{
+		Stack: stackaddrs.RootStackInstance,
+		Item: stackaddrs.ComponentInstance{
+			Component: stackaddrs.Component{Name: "c"},
+		},
+	}
+
+	buildPlan := func(depsInOrder []stackaddrs.AbsComponent) *Plan {
+		deps := collections.NewSet[stackaddrs.AbsComponent]()
+		for _, dep := range depsInOrder {
+			deps.Add(dep)
+		}
+		components := collections.NewMap[stackaddrs.AbsComponentInstance, *Component]()
+		components.Put(cmpCInstAddr, &Component{
+			Dependencies: deps,
+		})
+		return &Plan{Components: components}
+	}
+
+	planForward := buildPlan([]stackaddrs.AbsComponent{cmpAAddr, cmpBAddr})
+	planReverse := buildPlan([]stackaddrs.AbsComponent{cmpBAddr, cmpAAddr})
+
+	forwardBytes := planForward.canonicalDependencyBytes()
+	reverseBytes := planReverse.canonicalDependencyBytes()
+	if string(forwardBytes) != string(reverseBytes) {
+		t.Fatalf("canonicalized encodings differ based on dependency insertion order\nforward: %x\nreverse: %x", forwardBytes, reverseBytes)
+	}
+
+	wireForm := []byte("pretend-serialized-plan-body")
+	forwardFingerprint := planForward.Fingerprint(wireForm)
+	reverseFingerprint := planReverse.Fingerprint(wireForm)
+	if forwardFingerprint != reverseFingerprint {
+		t.Fatalf("fingerprints differ based on dependency insertion order\nforward: %x\nreverse: %x", forwardFingerprint, reverseFingerprint)
+	}
+}
+
+func TestPlan_fingerprintDiffersForDifferentDependencies(t *testing.T) {
+	cmpAAddr := stackaddrs.AbsComponent{
+		Stack: stackaddrs.RootStackInstance,
+		Item:  stackaddrs.Component{Name: "a"},
+	}
+	cmpBAddr := stackaddrs.AbsComponent{
+		Stack: stackaddrs.RootStackInstance,
+		Item:  stackaddrs.Component{Name: "b"},
+	}
+	cmpCInstAddr := stackaddrs.AbsComponentInstance{
+		Stack: stackaddrs.RootStackInstance,
+		Item: stackaddrs.ComponentInstance{
+			Component: stackaddrs.Component{Name: "c"},
+		},
+	}
+
+	buildPlan := func(dep stackaddrs.AbsComponent) *Plan {
+		deps := collections.NewSet[stackaddrs.AbsComponent]()
+		deps.Add(dep)
+		components := collections.NewMap[stackaddrs.AbsComponentInstance, *Component]()
+		components.Put(cmpCInstAddr, &Component{
+			Dependencies: deps,
+		})
+		return &Plan{Components: components}
+	}
+
+	wireForm := []byte("pretend-serialized-plan-body")
+	planA := buildPlan(cmpAAddr)
+	planB := buildPlan(cmpBAddr)
+
+	if planA.Fingerprint(wireForm) == planB.Fingerprint(wireForm) {
+		t.Fatalf("plans with different dependencies produced the same fingerprint")
+	}
+}
+
+// TestPlan_fingerprintDiffersForDifferentWireForm checks that two plans
+// with an identical dependency graph but different wire forms -- standing in for, say,
+// different resource instance changes -- must not collide, since that
+// would let a tampered or stale plan body pass an integrity check keyed on
+// the dependency graph alone.
+func TestPlan_fingerprintDiffersForDifferentWireForm(t *testing.T) {
+	cmpAAddr := stackaddrs.AbsComponent{
+		Stack: stackaddrs.RootStackInstance,
+		Item:  stackaddrs.Component{Name: "a"},
+	}
+	cmpCInstAddr := stackaddrs.AbsComponentInstance{
+		Stack: stackaddrs.RootStackInstance,
+		Item: stackaddrs.ComponentInstance{
+			Component: stackaddrs.Component{Name: "c"},
+		},
+	}
+
+	deps := collections.NewSet[stackaddrs.AbsComponent]()
+	deps.Add(cmpAAddr)
+	components := collections.NewMap[stackaddrs.AbsComponentInstance, *Component]()
+	components.Put(cmpCInstAddr, &Component{Dependencies: deps})
+	plan := &Plan{Components: components}
+
+	fingerprintA := plan.Fingerprint([]byte("resource changes: create 1 thing"))
+	fingerprintB := plan.Fingerprint([]byte("resource changes: create 100 things"))
+	if fingerprintA == fingerprintB {
+		t.Fatalf("fingerprints matched despite different wire forms")
+	}
+}
@@ -0,0 +1,266 @@
+package stackeval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform/internal/collections"
+	"github.com/hashicorp/terraform/internal/stacks/stackaddrs"
+)
+
+// ApplySchedulerEventKind identifies what happened to a component instance
+// node during an ApplyScheduler.Run call.
+type ApplySchedulerEventKind rune
+
+const (
+	// ApplySchedulerStarted is sent when a component instance's apply work
+	// function begins executing.
+	ApplySchedulerStarted ApplySchedulerEventKind = 'S'
+
+	// ApplySchedulerFinished is sent when a component instance's apply work
+	// function has returned, whether successfully or not. Err is set if it
+	// returned an error.
+	ApplySchedulerFinished ApplySchedulerEventKind = 'F'
+
+	// ApplySchedulerSkipped is sent for a component instance whose work
+	// function was never called, either because one of its dependencies
+	// failed or was skipped, or because the scheduler was not configured to
+	// continue on error and some other node had already failed by the time
+	// this one became ready to run. Err is the error that caused the skip,
+	// which may have originated several edges away or in another branch.
+	ApplySchedulerSkipped ApplySchedulerEventKind = 'K'
+)
+
+// ApplySchedulerEvent is sent on the channel returned by
+// ApplyScheduler.Events as nodes start, finish, or are skipped, so that a
+// caller can relay this information into the streaming apply protocol.
+type ApplySchedulerEvent struct {
+	Kind ApplySchedulerEventKind
+	Addr stackaddrs.AbsComponentInstance
+	Err  error
+}
+
+// ApplySchedulerFunc is the work function associated with a single
+// component instance node in an ApplyScheduler's DAG.
+type ApplySchedulerFunc func(ctx context.Context) error
+
+type applySchedulerNode struct {
+	addr stackaddrs.AbsComponentInstance
+	deps collections.Set[stackaddrs.AbsComponentInstance]
+	fn   ApplySchedulerFunc
+}
+
+// ApplyScheduler builds an explicit DAG of AbsComponentInstance nodes and
+// executes their associated work functions, honoring dependency order and
+// a caller-chosen parallelism budget.
+//
+// Callers Enqueue every node up front, along with the set of other nodes
+// it depends on, and then call Run once to execute them all.
+//
+// An ApplyScheduler is not safe for concurrent use: Enqueue must not be
+// called concurrently with itself or with Run, and Run must only be
+// called once.
+type ApplyScheduler struct {
+	// destroy indicates that this scheduler is sequencing a destroy plan,
+	// in which case dependency edges are honored in reverse: a component
+	// instance is not destroyed until everything that depends on it has
+	// already been destroyed.
+	destroy bool
+
+	// continueOnError allows independent branches of the DAG to keep
+	// applying even after some other branch's node fails. When false (the
+	// default) a failure stops further nodes from starting: any node that
+	// becomes ready to run after the failure is skipped, though nodes that
+	// were already dispatched still run. In either mode every node that
+	// transitively depends on the failed node is skipped.
+	continueOnError bool
+
+	nodes  map[stackaddrs.AbsComponentInstance]*applySchedulerNode
+	events chan ApplySchedulerEvent
+}
+
+// NewApplyScheduler constructs an ApplyScheduler. destroy should be true
+// when scheduling a destroy plan's component instances.
+func NewApplyScheduler(destroy bool) *ApplyScheduler {
+	return &ApplyScheduler{
+		destroy: destroy,
+		nodes:   make(map[stackaddrs.AbsComponentInstance]*applySchedulerNode),
+		events:  make(chan ApplySchedulerEvent),
+	}
+}
+
+// ContinueOnError opts the scheduler into letting independent branches of
+// the DAG keep applying even after some other branch's node fails. Nodes
+// that depend on the failed node are skipped either way.
+func (s *ApplyScheduler) ContinueOnError(continueOnError bool) {
+	s.continueOnError = continueOnError
+}
+
+// Enqueue registers a component instance and the work function that
+// applies it. deps is the set of other component instances that must
+// finish first (or, for a destroy scheduler, finish last).
+//
+// Each addr may only be enqueued once.
+func (s *ApplyScheduler) Enqueue(addr stackaddrs.AbsComponentInstance, deps collections.Set[stackaddrs.AbsComponentInstance], fn ApplySchedulerFunc) {
+	if _, exists := s.nodes[addr]; exists {
+		panic(fmt.Sprintf("duplicate Enqueue for %s", addr))
+	}
+	s.nodes[addr] = &applySchedulerNode{
+		addr: addr,
+		deps: deps,
+		fn:   fn,
+	}
+}
+
+// Events returns the channel that Run sends per-node start/finish/skip
+// events on. A caller that wants to observe progress must drain this
+// channel concurrently with calling Run, since the channel is unbuffered
+// and Run blocks on each send until it is received. The channel is closed
+// once Run returns.
+func (s *ApplyScheduler) Events() <-chan ApplySchedulerEvent {
+	return s.events
+}
+
+// Run executes every enqueued work function, starting a node only once
+// its dependencies (inverted, for a destroy scheduler) have all finished,
+// and never running more than maxParallel of them concurrently. A
+// maxParallel of zero or less is treated as one.
+//
+// Run returns the first error encountered, if any, once every node has
+// either completed or been skipped as a consequence of that failure.
+func (s *ApplyScheduler) Run(ctx context.Context, maxParallel int) error {
+	if maxParallel <= 0 {
+		maxParallel = 1
+	}
+	defer close(s.events)
+
+	addrs := make([]stackaddrs.AbsComponentInstance, 0, len(s.nodes))
+	for addr := range s.nodes {
+		addrs = append(addrs, addr)
+	}
+
+	type nodeState struct {
+		remaining int
+		done      bool
+		skipped   bool
+	}
+	states := make(map[stackaddrs.AbsComponentInstance]*nodeState, len(addrs))
+	notify := make(map[stackaddrs.AbsComponentInstance][]stackaddrs.AbsComponentInstance, len(addrs))
+	for _, addr := range addrs {
+		states[addr] = &nodeState{}
+	}
+	for _, addr := range addrs {
+		var dependsOn int
+		for _, other := range addrs {
+			if other == addr {
+				continue
+			}
+			var edge bool
+			if s.destroy {
+				// Reverse: addr waits for other if other depends on addr.
+				edge = s.nodes[other].deps.Has(addr)
+			} else {
+				edge = s.nodes[addr].deps.Has(other)
+			}
+			if edge {
+				dependsOn++
+				notify[other] = append(notify[other], addr)
+			}
+		}
+		states[addr].remaining = dependsOn
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	sem := make(chan struct{}, maxParallel)
+
+	var dispatch func(addr stackaddrs.AbsComponentInstance)
+	var finish func(addr stackaddrs.AbsComponentInstance, err error)
+	var markSkipped func(addr stackaddrs.AbsComponentInstance, cause error) []ApplySchedulerEvent
+
+	dispatch = func(addr stackaddrs.AbsComponentInstance) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			node := s.nodes[addr]
+			s.events <- ApplySchedulerEvent{Kind: ApplySchedulerStarted, Addr: addr}
+			err := node.fn(ctx)
+			s.events <- ApplySchedulerEvent{Kind: ApplySchedulerFinished, Addr: addr, Err: err}
+			finish(addr, err)
+		}()
+	}
+
+	// markSkipped records addr, and anything that transitively depends on
+	// it, as skipped and returns the corresponding events to send. It must
+	// be called with mu held, but deliberately doesn't send on s.events
+	// itself: that's left to the caller to do once mu is released, so that
+	// a slow events consumer can never block other nodes' finish handling.
+	markSkipped = func(addr stackaddrs.AbsComponentInstance, cause error) []ApplySchedulerEvent {
+		st := states[addr]
+		if st.done || st.skipped {
+			return nil
+		}
+		st.skipped = true
+		events := []ApplySchedulerEvent{{Kind: ApplySchedulerSkipped, Addr: addr, Err: cause}}
+		for _, next := range notify[addr] {
+			events = append(events, markSkipped(next, cause)...)
+		}
+		return events
+	}
+
+	finish = func(addr stackaddrs.AbsComponentInstance, err error) {
+		mu.Lock()
+
+		states[addr].done = true
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		var skipEvents []ApplySchedulerEvent
+		var toDispatch []stackaddrs.AbsComponentInstance
+		for _, next := range notify[addr] {
+			nst := states[next]
+			if nst.done || nst.skipped {
+				continue
+			}
+			if err != nil {
+				skipEvents = append(skipEvents, markSkipped(next, err)...)
+				continue
+			}
+			nst.remaining--
+			if nst.remaining == 0 {
+				if firstErr != nil && !s.continueOnError {
+					skipEvents = append(skipEvents, markSkipped(next, firstErr)...)
+					continue
+				}
+				toDispatch = append(toDispatch, next)
+			}
+		}
+
+		mu.Unlock()
+
+		for _, event := range skipEvents {
+			s.events <- event
+		}
+		for _, next := range toDispatch {
+			dispatch(next)
+		}
+	}
+
+	mu.Lock()
+	for _, addr := range addrs {
+		if states[addr].remaining == 0 {
+			dispatch(addr)
+		}
+	}
+	mu.Unlock()
+
+	wg.Wait()
+	return firstErr
+}
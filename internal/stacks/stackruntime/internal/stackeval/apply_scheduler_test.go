@@ -0,0 +1,197 @@
+package stackeval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/collections"
+	"github.com/hashicorp/terraform/internal/stacks/stackaddrs"
+	"github.com/hashicorp/terraform/internal/stacks/stacktest"
+)
+
+func testSchedulerComponentInstAddr(name string, key addrs.InstanceKey) stackaddrs.AbsComponentInstance {
+	return stackaddrs.AbsComponentInstance{
+		Stack: stackaddrs.RootStackInstance,
+		Item: stackaddrs.ComponentInstance{
+			Component: stackaddrs.Component{Name: name},
+			Key:       key,
+		},
+	}
+}
+
+func TestApplyScheduler_ordering(t *testing.T) {
+	// component.a has no dependencies, component.b depends on component.a,
+	// and component.c depends on component.b. A correct scheduler must
+	// therefore apply them in that order regardless of how much
+	// parallelism it's given, since the chain is strictly linear.
+	cmpA := testSchedulerComponentInstAddr("a", addrs.NoKey)
+	cmpB := testSchedulerComponentInstAddr("b", addrs.NoKey)
+	cmpC := testSchedulerComponentInstAddr("c", addrs.NoKey)
+
+	var mu sync.Mutex
+	var order []stackaddrs.AbsComponentInstance
+	record := func(addr stackaddrs.AbsComponentInstance) ApplySchedulerFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			order = append(order, addr)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	sched := NewApplyScheduler(false)
+	sched.Enqueue(cmpC, collections.NewSet(cmpB), record(cmpC))
+	sched.Enqueue(cmpA, collections.NewSet[stackaddrs.AbsComponentInstance](), record(cmpA))
+	sched.Enqueue(cmpB, collections.NewSet(cmpA), record(cmpB))
+
+	go func() {
+		for range sched.Events() {
+			// Draining is all this test needs; the streaming apply
+			// protocol is the real consumer of these events.
+		}
+	}()
+
+	if err := sched.Run(context.Background(), 4); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	stacktest.AssertHappensBefore(t, order, cmpA, cmpB)
+	stacktest.AssertHappensBefore(t, order, cmpB, cmpC)
+}
+
+func TestApplyScheduler_destroyOrderingIsReversed(t *testing.T) {
+	// Same dependency graph as above, but for a destroy plan component.c
+	// must be destroyed before component.b, which must be destroyed
+	// before component.a: the exact opposite of create order.
+	cmpA := testSchedulerComponentInstAddr("a", addrs.NoKey)
+	cmpB := testSchedulerComponentInstAddr("b", addrs.NoKey)
+	cmpC := testSchedulerComponentInstAddr("c", addrs.NoKey)
+
+	var mu sync.Mutex
+	var order []stackaddrs.AbsComponentInstance
+	record := func(addr stackaddrs.AbsComponentInstance) ApplySchedulerFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			order = append(order, addr)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	sched := NewApplyScheduler(true)
+	sched.Enqueue(cmpA, collections.NewSet[stackaddrs.AbsComponentInstance](), record(cmpA))
+	sched.Enqueue(cmpB, collections.NewSet(cmpA), record(cmpB))
+	sched.Enqueue(cmpC, collections.NewSet(cmpB), record(cmpC))
+
+	go func() {
+		for range sched.Events() {
+		}
+	}()
+
+	if err := sched.Run(context.Background(), 4); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	stacktest.AssertHappensBefore(t, order, cmpC, cmpB)
+	stacktest.AssertHappensBefore(t, order, cmpB, cmpA)
+}
+
+func TestApplyScheduler_cancelsDownstreamOnError(t *testing.T) {
+	cmpA := testSchedulerComponentInstAddr("a", addrs.NoKey)
+	cmpB := testSchedulerComponentInstAddr("b", addrs.NoKey)
+	cmpC := testSchedulerComponentInstAddr("c", addrs.NoKey) // independent branch
+
+	wantErr := fmt.Errorf("component.a failed")
+
+	var mu sync.Mutex
+	var ran []stackaddrs.AbsComponentInstance
+	track := func(addr stackaddrs.AbsComponentInstance, err error) ApplySchedulerFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			ran = append(ran, addr)
+			mu.Unlock()
+			return err
+		}
+	}
+
+	sched := NewApplyScheduler(false)
+	sched.Enqueue(cmpA, collections.NewSet[stackaddrs.AbsComponentInstance](), track(cmpA, wantErr))
+	sched.Enqueue(cmpB, collections.NewSet(cmpA), track(cmpB, nil))
+	sched.Enqueue(cmpC, collections.NewSet[stackaddrs.AbsComponentInstance](), track(cmpC, nil))
+
+	var skipped []stackaddrs.AbsComponentInstance
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range sched.Events() {
+			if event.Kind == ApplySchedulerSkipped {
+				mu.Lock()
+				skipped = append(skipped, event.Addr)
+				mu.Unlock()
+			}
+		}
+	}()
+
+	err := sched.Run(context.Background(), 4)
+	<-done
+	if err != wantErr {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, wantErr)
+	}
+
+	for _, addr := range ran {
+		if addr == cmpB {
+			t.Fatalf("component.b ran despite its dependency component.a failing")
+		}
+	}
+	if len(skipped) != 1 || skipped[0] != cmpB {
+		t.Fatalf("wrong skipped set: %#v", skipped)
+	}
+}
+
+func TestApplyScheduler_continueOnErrorAllowsIndependentBranches(t *testing.T) {
+	cmpA := testSchedulerComponentInstAddr("a", addrs.NoKey)
+	cmpB := testSchedulerComponentInstAddr("b", addrs.NoKey)
+	cmpC := testSchedulerComponentInstAddr("c", addrs.NoKey) // independent of a/b
+
+	wantErr := fmt.Errorf("component.a failed")
+
+	var mu sync.Mutex
+	var ran []stackaddrs.AbsComponentInstance
+	track := func(addr stackaddrs.AbsComponentInstance, err error) ApplySchedulerFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			ran = append(ran, addr)
+			mu.Unlock()
+			return err
+		}
+	}
+
+	sched := NewApplyScheduler(false)
+	sched.ContinueOnError(true)
+	sched.Enqueue(cmpA, collections.NewSet[stackaddrs.AbsComponentInstance](), track(cmpA, wantErr))
+	sched.Enqueue(cmpB, collections.NewSet(cmpA), track(cmpB, nil))
+	sched.Enqueue(cmpC, collections.NewSet[stackaddrs.AbsComponentInstance](), track(cmpC, nil))
+
+	go func() {
+		for range sched.Events() {
+		}
+	}()
+
+	err := sched.Run(context.Background(), 4)
+	if err != wantErr {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, wantErr)
+	}
+
+	var sawC bool
+	for _, addr := range ran {
+		if addr == cmpC {
+			sawC = true
+		}
+	}
+	if !sawC {
+		t.Fatalf("component.c, an independent branch, did not run despite continue-on-error")
+	}
+}
@@ -2,10 +2,9 @@ package stackeval
 
 import (
 	"context"
-	"slices"
+	"sync"
 	"testing"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/google/go-cmp/cmp"
 	"github.com/zclconf/go-cty/cty"
 
@@ -18,6 +17,7 @@ import (
 	"github.com/hashicorp/terraform/internal/stacks/stackaddrs"
 	"github.com/hashicorp/terraform/internal/stacks/stackplan"
 	"github.com/hashicorp/terraform/internal/stacks/stackstate"
+	"github.com/hashicorp/terraform/internal/stacks/stacktest"
 	"github.com/hashicorp/terraform/internal/terraform"
 )
 
@@ -179,22 +179,96 @@ func TestApply_componentOrdering(t *testing.T) {
 			t.Fatalf("wrong dependencies for component.b[\"i\"]\n%s", diff)
 		}
 	}
-}
 
-func sliceElementsInRelativeOrder[S ~[]E, E comparable](s S, v1, v2 E) bool {
-	idx1 := slices.Index(s, v1)
-	idx2 := slices.Index(s, v2)
-	if idx1 < 0 || idx2 < 0 {
-		// both values must actually be present for this test to be meaningful
-		return false
+	// Now we'll actually exercise the apply-time ordering, using an
+	// ApplyScheduler built directly from this plan's component
+	// dependencies and fake per-instance work functions in place of real
+	// provider calls. This lets us assert the ordering deterministically,
+	// rather than relying on the mock provider calls above happening to
+	// race in the right order.
+	instAddrs := []stackaddrs.AbsComponentInstance{
+		cmpAInstAddr,
+		cmpBInst1Addr,
+		cmpBInst2Addr,
+		cmpBInst3Addr,
+		cmpCInstAddr,
 	}
-	return idx1 < idx2
-}
 
-func assertSliceElementsInRelativeOrder[S ~[]E, E comparable](t *testing.T, s S, v1, v2 E) {
-	t.Helper()
+	// forwardDeps maps each component instance to the other instances that
+	// must be applied before it in normal (non-destroy) order, derived
+	// from the plan's computed component dependencies. destroyDeps is its
+	// inverse: what must be destroyed before each instance when tearing
+	// everything down.
+	forwardDeps := make(map[stackaddrs.AbsComponentInstance][]stackaddrs.AbsComponentInstance)
+	for _, instAddr := range instAddrs {
+		for _, depCmpAddr := range plan.Components.Get(instAddr).Dependencies.Elems() {
+			for _, depInstAddr := range instAddrs {
+				if depInstAddr.Item.Component == depCmpAddr.Item {
+					forwardDeps[instAddr] = append(forwardDeps[instAddr], depInstAddr)
+				}
+			}
+		}
+	}
+	destroyDeps := make(map[stackaddrs.AbsComponentInstance][]stackaddrs.AbsComponentInstance)
+	for instAddr, deps := range forwardDeps {
+		for _, depInstAddr := range deps {
+			destroyDeps[depInstAddr] = append(destroyDeps[depInstAddr], instAddr)
+		}
+	}
 
-	if !sliceElementsInRelativeOrder(s, v1, v2) {
-		t.Fatalf("incorrect element order\ngot: %s\nwant: %#v before %#v", spew.Sdump(s), v1, v2)
+	buildScheduler := func(destroy bool) (*ApplyScheduler, *[]stackaddrs.AbsComponentInstance) {
+		var mu sync.Mutex
+		var order []stackaddrs.AbsComponentInstance
+
+		sched := NewApplyScheduler(destroy)
+		for _, instAddr := range instAddrs {
+			deps := collections.NewSet[stackaddrs.AbsComponentInstance]()
+			for _, depInstAddr := range forwardDeps[instAddr] {
+				deps.Add(depInstAddr)
+			}
+
+			instAddr := instAddr
+			sched.Enqueue(instAddr, deps, func(ctx context.Context) error {
+				mu.Lock()
+				order = append(order, instAddr)
+				mu.Unlock()
+				return nil
+			})
+		}
+		return sched, &order
 	}
+
+	runScheduler := func(t *testing.T, sched *ApplyScheduler) {
+		t.Helper()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range sched.Events() {
+				// The real streaming apply protocol is the production
+				// consumer of these events; this test only needs to
+				// drain them so Run doesn't block.
+			}
+		}()
+
+		if err := sched.Run(ctx, 4); err != nil {
+			t.Fatalf("scheduler apply failed: %s", err)
+		}
+		<-done
+	}
+
+	t.Run("normal mode ordering", func(t *testing.T) {
+		sched, order := buildScheduler(false)
+		runScheduler(t, sched)
+
+		stacktest.AssertTopologicalOrder(t, *order, forwardDeps)
+	})
+
+	t.Run("destroy mode ordering is reversed", func(t *testing.T) {
+		sched, order := buildScheduler(true)
+		runScheduler(t, sched)
+
+		stacktest.AssertTopologicalOrder(t, *order, destroyDeps)
+	})
 }
+
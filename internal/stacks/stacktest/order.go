@@ -0,0 +1,133 @@
+// Package stacktest provides small assertion helpers, shared across the
+// stacks packages' test suites, for checking that recorded event orderings
+// respect expected "happens before" relationships and dependency graphs.
+package stacktest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sliceElementsInRelativeOrder reports whether v1 appears before v2 in s.
+// Both values must actually be present in s for the result to be
+// meaningful.
+func sliceElementsInRelativeOrder[S ~[]E, E comparable](s S, v1, v2 E) bool {
+	idx1 := slices.Index(s, v1)
+	idx2 := slices.Index(s, v2)
+	if idx1 < 0 || idx2 < 0 {
+		return false
+	}
+	return idx1 < idx2
+}
+
+// AssertHappensBefore fails the test unless v1 appears before v2 in s.
+func AssertHappensBefore[S ~[]E, E comparable](t *testing.T, s S, v1, v2 E) {
+	t.Helper()
+
+	if !sliceElementsInRelativeOrder(s, v1, v2) {
+		t.Fatalf("incorrect element order\ngot: %#v\nwant: %#v before %#v", s, v1, v2)
+	}
+}
+
+// AssertAllBefore fails the test unless every element of before appears
+// earlier in s than every element of after.
+func AssertAllBefore[S ~[]E, E comparable](t *testing.T, s S, before, after []E) {
+	t.Helper()
+
+	for _, v1 := range before {
+		for _, v2 := range after {
+			if !sliceElementsInRelativeOrder(s, v1, v2) {
+				t.Fatalf("incorrect element order\ngot: %#v\nwant: %#v before %#v", s, v1, v2)
+			}
+		}
+	}
+}
+
+// AssertTopologicalOrder fails the test unless order is a valid
+// linearization of the dependency graph described by deps, where
+// deps[e] lists the elements that must appear before e in order.
+//
+// It first checks deps itself for cycles, since a cyclic dependency graph
+// has no valid linearization and the resulting failure is clearer as a
+// reported cycle than as an arbitrary ordering violation somewhere inside
+// it. If deps is acyclic, it then reports the first offending edge it
+// finds -- e.g. "component.c ran before component.b, violating edge
+// component.b->component.c" -- rather than leaving the caller to spot the
+// problem in a raw slice dump.
+func AssertTopologicalOrder[E comparable](t *testing.T, order []E, deps map[E][]E) {
+	t.Helper()
+
+	if cycle := findCycle(deps); cycle != nil {
+		t.Fatalf("dependency graph has a cycle: %s", formatCycle(cycle))
+	}
+
+	for e, before := range deps {
+		for _, dep := range before {
+			if !sliceElementsInRelativeOrder(order, dep, e) {
+				t.Fatalf("%v ran before %v, violating edge %v->%v", e, dep, dep, e)
+			}
+		}
+	}
+}
+
+// findCycle does a depth-first search over deps looking for a cycle,
+// returning the elements of the cycle in dependency order if it finds
+// one, or nil if deps is acyclic.
+func findCycle[E comparable](deps map[E][]E) []E {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[E]int, len(deps))
+	var path []E
+
+	var visit func(e E) []E
+	visit = func(e E) []E {
+		switch state[e] {
+		case done:
+			return nil
+		case visiting:
+			for i, prior := range path {
+				if prior == e {
+					cycle := make([]E, 0, len(path)-i+1)
+					cycle = append(cycle, path[i:]...)
+					cycle = append(cycle, e)
+					return cycle
+				}
+			}
+			// Unreachable: e is marked "visiting" only while it's in path.
+			return []E{e}
+		}
+
+		state[e] = visiting
+		path = append(path, e)
+		for _, dep := range deps[e] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[e] = done
+		return nil
+	}
+
+	for e := range deps {
+		if state[e] == unvisited {
+			if cycle := visit(e); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func formatCycle[E comparable](cycle []E) string {
+	parts := make([]string, len(cycle))
+	for i, e := range cycle {
+		parts[i] = fmt.Sprintf("%v", e)
+	}
+	return strings.Join(parts, " -> ")
+}
@@ -0,0 +1,110 @@
+package stacktest
+
+import "testing"
+
+func TestAssertHappensBefore(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		AssertHappensBefore(t, []string{"a", "b", "c"}, "a", "c")
+	})
+
+	t.Run("violation is reported as a failure", func(t *testing.T) {
+		passed := t.Run("inner", func(t *testing.T) {
+			AssertHappensBefore(t, []string{"b", "a"}, "a", "b")
+		})
+		if passed {
+			t.Fatalf("expected inner subtest to fail, but it passed")
+		}
+	})
+}
+
+func TestAssertAllBefore(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		AssertAllBefore(t,
+			[]string{"a", "b", "c", "d"},
+			[]string{"a", "b"},
+			[]string{"c", "d"},
+		)
+	})
+
+	t.Run("violation is reported as a failure", func(t *testing.T) {
+		passed := t.Run("inner", func(t *testing.T) {
+			AssertAllBefore(t,
+				[]string{"a", "c", "b", "d"},
+				[]string{"a", "b"},
+				[]string{"c", "d"},
+			)
+		})
+		if passed {
+			t.Fatalf("expected inner subtest to fail, but it passed")
+		}
+	})
+}
+
+func TestAssertTopologicalOrder(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		// b depends on a, c depends on b.
+		order := []string{"a", "b", "c"}
+		deps := map[string][]string{
+			"b": {"a"},
+			"c": {"b"},
+		}
+		AssertTopologicalOrder(t, order, deps)
+	})
+
+	t.Run("violated edge is reported as a failure", func(t *testing.T) {
+		// c depends on b, but ran first.
+		order := []string{"c", "a", "b"}
+		deps := map[string][]string{
+			"b": {"a"},
+			"c": {"b"},
+		}
+		passed := t.Run("inner", func(t *testing.T) {
+			AssertTopologicalOrder(t, order, deps)
+		})
+		if passed {
+			t.Fatalf("expected inner subtest to fail, but it passed")
+		}
+	})
+
+	t.Run("cycle is reported as a failure", func(t *testing.T) {
+		order := []string{"a", "b", "c"}
+		deps := map[string][]string{
+			"a": {"c"},
+			"b": {"a"},
+			"c": {"b"},
+		}
+		passed := t.Run("inner", func(t *testing.T) {
+			AssertTopologicalOrder(t, order, deps)
+		})
+		if passed {
+			t.Fatalf("expected inner subtest to fail, but it passed")
+		}
+	})
+}
+
+func TestFindCycle(t *testing.T) {
+	t.Run("acyclic", func(t *testing.T) {
+		deps := map[string][]string{
+			"b": {"a"},
+			"c": {"b"},
+		}
+		if cycle := findCycle(deps); cycle != nil {
+			t.Fatalf("unexpected cycle reported: %v", cycle)
+		}
+	})
+
+	t.Run("cyclic", func(t *testing.T) {
+		deps := map[string][]string{
+			"a": {"c"},
+			"b": {"a"},
+			"c": {"b"},
+		}
+		cycle := findCycle(deps)
+		if cycle == nil {
+			t.Fatalf("expected a cycle to be reported, got none")
+		}
+		if len(cycle) < 2 || cycle[0] != cycle[len(cycle)-1] {
+			t.Fatalf("cycle does not start and end at the same element: %v", cycle)
+		}
+	})
+}